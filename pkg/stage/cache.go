@@ -0,0 +1,165 @@
+package stage
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheKey identifies a cached lookup by IP and the language its localized
+// names were rendered in, since the same IP can be cached once per language.
+type cacheKey struct {
+	ip   string
+	lang string
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	details *IPDetails
+}
+
+// CacheStats reports cumulative counters for an IPInfo's lookup cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// lruCache is a bounded, concurrency-safe LRU cache of *IPDetails keyed by
+// (ip, language), sized for high-QPS scans where the same IPs (or hosts
+// within the same CIDR range) are looked up repeatedly.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[cacheKey]*list.Element
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[cacheKey]*list.Element, capacity),
+	}
+}
+
+func (c *lruCache) get(key cacheKey) (*IPDetails, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+	atomic.AddUint64(&c.hits, 1)
+	return elem.Value.(*cacheEntry).details, true
+}
+
+func (c *lruCache) put(key cacheKey, details *IPDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		elem.Value.(*cacheEntry).details = details
+		return
+	}
+
+	elem := c.ll.PushFront(&cacheEntry{key: key, details: details})
+	c.items[key] = elem
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).key)
+			atomic.AddUint64(&c.evictions, 1)
+		}
+	}
+}
+
+func (c *lruCache) stats() CacheStats {
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+	}
+}
+
+// clear drops every cached entry without touching the hit/miss/eviction
+// counters, so a hot-reloaded database can't keep serving stale lookups.
+func (c *lruCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[cacheKey]*list.Element, c.capacity)
+}
+
+// lookupBatchWorkers bounds the fan-out used by LookupBatch so a huge CIDR
+// scan doesn't open thousands of goroutines at once.
+const lookupBatchWorkers = 32
+
+// LookupBatch resolves every IP in ips, sharing the configured cache across
+// a bounded worker pool so duplicate IPs in the batch only hit the mmdb
+// readers once. IPs that fail to resolve are omitted from the result.
+func (i *IPInfo) LookupBatch(ips []string) map[string]*IPDetails {
+	results := make(map[string]*IPDetails, len(ips))
+	var mu sync.Mutex
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+
+	workers := lookupBatchWorkers
+	if workers > len(ips) {
+		workers = len(ips)
+	}
+	if workers == 0 {
+		return results
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				details, err := i.GetIPInfo(ip)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				results[ip] = details
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		jobs <- ip
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Stats returns the cumulative cache hit/miss/eviction counters. It returns
+// the zero value if no cache was configured (see NewIPInfoWithCache).
+func (i *IPInfo) Stats() CacheStats {
+	if i.cache == nil {
+		return CacheStats{}
+	}
+	return i.cache.stats()
+}
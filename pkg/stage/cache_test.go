@@ -0,0 +1,87 @@
+package stage
+
+import "testing"
+
+func TestLRUCache_HitsAndMisses(t *testing.T) {
+	c := newLRUCache(2)
+
+	if _, ok := c.get(cacheKey{ip: "1.1.1.1", lang: "en"}); ok {
+		t.Fatal("get on empty cache returned a hit")
+	}
+
+	c.put(cacheKey{ip: "1.1.1.1", lang: "en"}, &IPDetails{Country: "A"})
+	details, ok := c.get(cacheKey{ip: "1.1.1.1", lang: "en"})
+	if !ok || details.Country != "A" {
+		t.Fatalf("get after put = %v, %v; want hit with Country=A", details, ok)
+	}
+
+	stats := c.stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want Hits=1 Misses=1", stats)
+	}
+}
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.put(cacheKey{ip: "1.1.1.1", lang: "en"}, &IPDetails{Country: "A"})
+	c.put(cacheKey{ip: "2.2.2.2", lang: "en"}, &IPDetails{Country: "B"})
+
+	// Touch the first entry so it becomes most-recently-used.
+	if _, ok := c.get(cacheKey{ip: "1.1.1.1", lang: "en"}); !ok {
+		t.Fatal("expected hit on 1.1.1.1")
+	}
+
+	// Adding a third entry should evict 2.2.2.2, the least recently used.
+	c.put(cacheKey{ip: "3.3.3.3", lang: "en"}, &IPDetails{Country: "C"})
+
+	if _, ok := c.get(cacheKey{ip: "2.2.2.2", lang: "en"}); ok {
+		t.Error("expected 2.2.2.2 to have been evicted")
+	}
+	if _, ok := c.get(cacheKey{ip: "1.1.1.1", lang: "en"}); !ok {
+		t.Error("expected 1.1.1.1 to survive eviction")
+	}
+	if _, ok := c.get(cacheKey{ip: "3.3.3.3", lang: "en"}); !ok {
+		t.Error("expected 3.3.3.3 to be present")
+	}
+
+	stats := c.stats()
+	if stats.Evictions != 1 {
+		t.Errorf("stats.Evictions = %d, want 1", stats.Evictions)
+	}
+}
+
+func TestLRUCache_PutExistingKeyUpdatesValue(t *testing.T) {
+	c := newLRUCache(2)
+
+	key := cacheKey{ip: "1.1.1.1", lang: "en"}
+	c.put(key, &IPDetails{Country: "A"})
+	c.put(key, &IPDetails{Country: "B"})
+
+	details, ok := c.get(key)
+	if !ok || details.Country != "B" {
+		t.Fatalf("get after overwrite = %v, %v; want hit with Country=B", details, ok)
+	}
+	if c.ll.Len() != 1 {
+		t.Errorf("cache length = %d, want 1 (overwrite should not grow the list)", c.ll.Len())
+	}
+}
+
+func TestLRUCache_Clear(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.put(cacheKey{ip: "1.1.1.1", lang: "en"}, &IPDetails{Country: "A"})
+	c.put(cacheKey{ip: "2.2.2.2", lang: "en"}, &IPDetails{Country: "B"})
+
+	c.clear()
+
+	if c.ll.Len() != 0 {
+		t.Errorf("cache length after clear = %d, want 0", c.ll.Len())
+	}
+	if _, ok := c.get(cacheKey{ip: "1.1.1.1", lang: "en"}); ok {
+		t.Error("expected 1.1.1.1 to be gone after clear")
+	}
+	if _, ok := c.get(cacheKey{ip: "2.2.2.2", lang: "en"}); ok {
+		t.Error("expected 2.2.2.2 to be gone after clear")
+	}
+}
@@ -0,0 +1,108 @@
+package stage
+
+import (
+	"errors"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// ErrUnsupported is returned by a GeoReader method that the underlying
+// database flavor doesn't provide (e.g. calling Enterprise() on a reader
+// backed by a GeoLite2-City database).
+var ErrUnsupported = errors.New("stage: operation not supported by this database")
+
+// GeoReader abstracts lookups across the different MaxMind/GeoIP2 database
+// flavors so IPInfo can enrich results from whichever databases an operator
+// has configured, without caring which one backs a given field.
+type GeoReader interface {
+	Country(ip net.IP) (*geoip2.Country, error)
+	City(ip net.IP) (*geoip2.City, error)
+	ASN(ip net.IP) (*geoip2.ASN, error)
+	AnonymousIP(ip net.IP) (*geoip2.AnonymousIP, error)
+	Enterprise(ip net.IP) (*geoip2.Enterprise, error)
+	IsEmpty() bool
+}
+
+// dbKind identifies which geoip2 query an mmdbReader's underlying file
+// supports.
+type dbKind int
+
+const (
+	dbKindCity dbKind = iota
+	dbKindASN
+	dbKindCountry
+	dbKindEnterprise
+	dbKindAnonymousIP
+)
+
+// mmdbReader adapts a single opened *geoip2.Reader to GeoReader, dispatching
+// only the query that matches the database it was opened from.
+type mmdbReader struct {
+	reader *geoip2.Reader
+	kind   dbKind
+}
+
+func newMMDBReader(reader *geoip2.Reader, kind dbKind) *mmdbReader {
+	return &mmdbReader{reader: reader, kind: kind}
+}
+
+func (r *mmdbReader) Country(ip net.IP) (*geoip2.Country, error) {
+	if r == nil || r.kind != dbKindCountry {
+		return nil, ErrUnsupported
+	}
+	return r.reader.Country(ip)
+}
+
+func (r *mmdbReader) City(ip net.IP) (*geoip2.City, error) {
+	if r == nil || r.kind != dbKindCity {
+		return nil, ErrUnsupported
+	}
+	return r.reader.City(ip)
+}
+
+func (r *mmdbReader) ASN(ip net.IP) (*geoip2.ASN, error) {
+	if r == nil || r.kind != dbKindASN {
+		return nil, ErrUnsupported
+	}
+	return r.reader.ASN(ip)
+}
+
+func (r *mmdbReader) AnonymousIP(ip net.IP) (*geoip2.AnonymousIP, error) {
+	if r == nil || r.kind != dbKindAnonymousIP {
+		return nil, ErrUnsupported
+	}
+	return r.reader.AnonymousIP(ip)
+}
+
+func (r *mmdbReader) Enterprise(ip net.IP) (*geoip2.Enterprise, error) {
+	if r == nil || r.kind != dbKindEnterprise {
+		return nil, ErrUnsupported
+	}
+	return r.reader.Enterprise(ip)
+}
+
+func (r *mmdbReader) IsEmpty() bool {
+	return r == nil || r.reader == nil
+}
+
+func (r *mmdbReader) Close() error {
+	if r == nil || r.reader == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
+
+// emptyReader is a GeoReader that reports empty and rejects every lookup.
+// It's used as the zero value for optional databases that weren't
+// configured, so callers don't need to nil-check before querying.
+type emptyReader struct{}
+
+func (emptyReader) Country(ip net.IP) (*geoip2.Country, error) { return nil, ErrUnsupported }
+func (emptyReader) City(ip net.IP) (*geoip2.City, error)       { return nil, ErrUnsupported }
+func (emptyReader) ASN(ip net.IP) (*geoip2.ASN, error)         { return nil, ErrUnsupported }
+func (emptyReader) AnonymousIP(ip net.IP) (*geoip2.AnonymousIP, error) {
+	return nil, ErrUnsupported
+}
+func (emptyReader) Enterprise(ip net.IP) (*geoip2.Enterprise, error) { return nil, ErrUnsupported }
+func (emptyReader) IsEmpty() bool                                    { return true }
@@ -0,0 +1,78 @@
+package stage
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMMDBReader_DispatchesByKind(t *testing.T) {
+	ip := net.ParseIP("8.8.8.8")
+	r := &mmdbReader{kind: dbKindCity}
+
+	if _, err := r.ASN(ip); err != ErrUnsupported {
+		t.Errorf("City-kind reader ASN() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.Country(ip); err != ErrUnsupported {
+		t.Errorf("City-kind reader Country() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.AnonymousIP(ip); err != ErrUnsupported {
+		t.Errorf("City-kind reader AnonymousIP() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.Enterprise(ip); err != ErrUnsupported {
+		t.Errorf("City-kind reader Enterprise() = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestMMDBReader_NilReceiver(t *testing.T) {
+	var r *mmdbReader
+	ip := net.ParseIP("8.8.8.8")
+
+	if _, err := r.City(ip); err != ErrUnsupported {
+		t.Errorf("nil *mmdbReader City() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.ASN(ip); err != ErrUnsupported {
+		t.Errorf("nil *mmdbReader ASN() = %v, want ErrUnsupported", err)
+	}
+	if !r.IsEmpty() {
+		t.Error("nil *mmdbReader IsEmpty() = false, want true")
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("nil *mmdbReader Close() = %v, want nil", err)
+	}
+}
+
+func TestMMDBReader_IsEmpty(t *testing.T) {
+	r := &mmdbReader{kind: dbKindASN}
+	if !r.IsEmpty() {
+		t.Error("mmdbReader with nil underlying reader: IsEmpty() = false, want true")
+	}
+}
+
+func TestEmptyReader_RejectsEveryLookup(t *testing.T) {
+	var r emptyReader
+	ip := net.ParseIP("8.8.8.8")
+
+	if !r.IsEmpty() {
+		t.Error("emptyReader.IsEmpty() = false, want true")
+	}
+	if _, err := r.Country(ip); err != ErrUnsupported {
+		t.Errorf("emptyReader.Country() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.City(ip); err != ErrUnsupported {
+		t.Errorf("emptyReader.City() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.ASN(ip); err != ErrUnsupported {
+		t.Errorf("emptyReader.ASN() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.AnonymousIP(ip); err != ErrUnsupported {
+		t.Errorf("emptyReader.AnonymousIP() = %v, want ErrUnsupported", err)
+	}
+	if _, err := r.Enterprise(ip); err != ErrUnsupported {
+		t.Errorf("emptyReader.Enterprise() = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestCloseGeoReader_IgnoresNonMMDBReader(t *testing.T) {
+	// Should not panic on a GeoReader that isn't backed by an *mmdbReader.
+	closeGeoReader(emptyReader{})
+}
@@ -1,22 +1,114 @@
 package stage
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/oschwald/geoip2-golang"
 )
 
 type IPInfo struct {
-	cityReader *geoip2.Reader
-	asnReader  *geoip2.Reader
-	dbDir      string
-	mu         sync.RWMutex
+	cityReader       GeoReader
+	asnReader        GeoReader
+	enterpriseReader GeoReader
+	anonymousReader  GeoReader
+	countryReader    GeoReader
+
+	dbDir string
+	mu    sync.RWMutex
+
+	cityDBURL string
+	asnDBURL  string
+
+	// cityDBPath/asnDBPath override the default "<dbDir>/GeoLite2-*.mmdb"
+	// locations, e.g. to point at an IPinfo/DB-IP/private-mirror MMDB.
+	cityDBPath string
+	asnDBPath  string
+
+	// offline disables the http.Get fallback in ensureDatabases; missing
+	// databases become a hard error instead of a silent network call.
+	offline bool
+
+	// MaxMindAccountID and MaxMindLicenseKey, when both set, make
+	// ensureDatabases and the scheduled updater fetch databases from the
+	// official MaxMind permalink endpoint instead of cityDBURL/asnDBURL.
+	MaxMindAccountID  string
+	MaxMindLicenseKey string
+
+	// UpdateInterval, when non-zero, enables a background updater that
+	// periodically re-downloads the databases if the remote copy changed.
+	UpdateInterval time.Duration
+
+	watcher   *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+
+	cache *lruCache
+
+	// DefaultLanguage selects which localized name GetIPInfo returns for
+	// fields like Country/City/Region. Defaults to "en". Use GetIPInfoLang
+	// to request a specific language per call instead.
+	DefaultLanguage string
+}
+
+// Options configures NewIPInfo. Every field is optional: each one has a
+// sensible default or is simply left disabled.
+type Options struct {
+	// DBDir holds the GeoLite2-City.mmdb/GeoLite2-ASN.mmdb files, and is
+	// downloaded into if they're missing. Defaults to ~/.zscan/geoip.
+	DBDir string
+
+	// EnterpriseDBPath, AnonymousIPDBPath and CountryDBPath point at
+	// additional, optional MMDB files. They are opened if present but never
+	// downloaded - these are paid MaxMind products the operator must supply.
+	// CountryDBPath, when set, overrides the Country/CountryCode/CountryNames
+	// fields the City database already populates - useful when an operator
+	// keeps a standalone Country product updated on a different cadence.
+	EnterpriseDBPath  string
+	AnonymousIPDBPath string
+	CountryDBPath     string
+
+	// CityDBURL and ASNDBURL override the default GitHub mirror used to
+	// fetch the free GeoLite2 databases.
+	CityDBURL string
+	ASNDBURL  string
+
+	// CityDBPath and ASNDBPath, when set, are opened directly instead of
+	// "<DBDir>/GeoLite2-{City,ASN}.mmdb" and are never downloaded into -
+	// use these to point at MMDBs from IPinfo, DB-IP, or a private MaxMind
+	// mirror without symlinking into DBDir.
+	CityDBPath string
+	ASNDBPath  string
+
+	// Offline disables the http.Get/MaxMind download fallback entirely.
+	// If the expected database files aren't already present, NewIPInfo
+	// returns an error instead of reaching out to the network - required
+	// for air-gapped scanning environments.
+	Offline bool
+
+	MaxMindAccountID  string
+	MaxMindLicenseKey string
+	UpdateInterval    time.Duration
+
+	// CacheSize, when non-zero, bounds an in-process LRU cache of resolved
+	// lookups keyed by (ip, language). Recommended for high-QPS scans of
+	// large CIDR ranges where the same IPs recur; a reasonable size is
+	// around 100000.
+	CacheSize int
+
+	// DefaultLanguage selects which localized name GetIPInfo returns.
+	// Defaults to "en". This is the plumbing a --geoip-lang CLI flag would
+	// set, but this tree has no cmd/ entrypoint to add that flag to.
+	DefaultLanguage string
 }
 
 type IPDetails struct {
@@ -44,14 +136,33 @@ type IPDetails struct {
 	IsProxy        bool   `json:"is_proxy,omitempty"`
 	IsTorExitNode  bool   `json:"is_tor_exit_node,omitempty"`
 	AccuracyRadius uint16 `json:"accuracy_radius,omitempty"`
+
+	// CountryNames and CityNames hold every localized name MaxMind shipped
+	// for this record (keyed by language code, e.g. "en", "zh-CN", "ja"),
+	// for consumers that want all translations rather than just the one
+	// selected via GetIPInfoLang.
+	CountryNames map[string]string `json:"country_names,omitempty"`
+	CityNames    map[string]string `json:"city_names,omitempty"`
 }
 
 const (
 	cityDBURL = "https://raw.githubusercontent.com/zcyberseclab/zscan/main/data/GeoLite2-City.mmdb"
 	asnDBURL  = "https://raw.githubusercontent.com/zcyberseclab/zscan/main/data/GeoLite2-ASN.mmdb"
+
+	cityDBName = "GeoLite2-City.mmdb"
+	asnDBName  = "GeoLite2-ASN.mmdb"
+
+	// maxMindPermalinkURL is the official "permalink" download endpoint,
+	// which requires an account ID (as HTTP basic auth user) and license key.
+	maxMindPermalinkURL = "https://download.maxmind.com/geoip/databases/%s/download?suffix=tar.gz"
 )
 
-func NewIPInfo(dbDir string) (*IPInfo, error) {
+// NewIPInfo opens (downloading if necessary) the GeoLite2-City and
+// GeoLite2-ASN databases under opts.DBDir, plus any optional Enterprise,
+// Anonymous IP or Country databases named in opts. A zero Options{} behaves
+// like the previous NewIPInfo(dbDir) did, using the default GitHub mirror.
+func NewIPInfo(opts Options) (*IPInfo, error) {
+	dbDir := opts.DBDir
 	if dbDir == "" {
 		userHome, err := os.UserHomeDir()
 		if err != nil {
@@ -65,19 +176,343 @@ func NewIPInfo(dbDir string) (*IPInfo, error) {
 	}
 
 	i := &IPInfo{
-		dbDir: dbDir,
-		mu:    sync.RWMutex{},
+		dbDir:             dbDir,
+		cityDBURL:         cityDBURL,
+		asnDBURL:          asnDBURL,
+		MaxMindAccountID:  opts.MaxMindAccountID,
+		MaxMindLicenseKey: opts.MaxMindLicenseKey,
+		UpdateInterval:    opts.UpdateInterval,
+		enterpriseReader:  emptyReader{},
+		anonymousReader:   emptyReader{},
+		countryReader:     emptyReader{},
+		mu:                sync.RWMutex{},
+	}
+	if opts.CityDBURL != "" {
+		i.cityDBURL = opts.CityDBURL
+	}
+	if opts.ASNDBURL != "" {
+		i.asnDBURL = opts.ASNDBURL
+	}
+	i.cityDBPath = opts.CityDBPath
+	i.asnDBPath = opts.ASNDBPath
+	i.offline = opts.Offline
+	if opts.CacheSize > 0 {
+		i.cache = newLRUCache(opts.CacheSize)
+	}
+	i.DefaultLanguage = opts.DefaultLanguage
+	if i.DefaultLanguage == "" {
+		i.DefaultLanguage = "en"
 	}
 
-	err := i.ensureDatabases()
-	if err != nil {
+	if err := i.ensureDatabases(); err != nil {
+		return nil, err
+	}
+
+	if err := i.openOptionalDatabases(opts); err != nil {
+		i.Close()
 		return nil, err
 	}
 
 	return i, nil
 }
 
+// NewIPInfoWithCache is a convenience wrapper around NewIPInfo that also
+// enables the bounded LRU lookup cache; equivalent to setting Options.CacheSize.
+func NewIPInfoWithCache(dbDir string, size int) (*IPInfo, error) {
+	return NewIPInfo(Options{DBDir: dbDir, CacheSize: size})
+}
+
+// openOptionalDatabases opens the Enterprise/Anonymous IP/Country mmdb files
+// named in opts, if any. Unlike the core City/ASN databases these are never
+// downloaded - they're paid MaxMind products the operator must supply.
+func (i *IPInfo) openOptionalDatabases(opts Options) error {
+	if opts.EnterpriseDBPath != "" {
+		reader, err := geoip2.Open(opts.EnterpriseDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open enterprise database: %v", err)
+		}
+		i.enterpriseReader = newMMDBReader(reader, dbKindEnterprise)
+	}
+
+	if opts.AnonymousIPDBPath != "" {
+		reader, err := geoip2.Open(opts.AnonymousIPDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open anonymous IP database: %v", err)
+		}
+		i.anonymousReader = newMMDBReader(reader, dbKindAnonymousIP)
+	}
+
+	if opts.CountryDBPath != "" {
+		reader, err := geoip2.Open(opts.CountryDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open country database: %v", err)
+		}
+		i.countryReader = newMMDBReader(reader, dbKindCountry)
+	}
+
+	return nil
+}
+
+// IsEmpty reports whether i is usable for GeoIP enrichment. A *IPInfo
+// returned by NewIPInfo always has both the city and ASN readers open -
+// NewIPInfo errors out otherwise - so in practice this is purely a
+// nil-receiver convenience: it lets callers like iprule.FilterTargets skip
+// enrichment when they were handed a nil *IPInfo (enrichment not
+// configured at all) instead of having to nil-check it themselves.
+func (i *IPInfo) IsEmpty() bool {
+	return i == nil
+}
+
+// SetDatabaseURLs overrides the default GitHub mirror used to fetch the
+// GeoLite2 databases. NewIPInfo has already downloaded any missing databases
+// by the time this can be called, so the new URLs are used only by later
+// downloads from StartAutoUpdate.
+func (i *IPInfo) SetDatabaseURLs(cityURL, asnURL string) {
+	i.cityDBURL = cityURL
+	i.asnDBURL = asnURL
+}
+
+// cityDBLocation and asnDBLocation return the path/basename pair Watch and
+// reloadCityDB/reloadASNDB should watch and (re)open: the cityDBPath/
+// asnDBPath override if one was configured, otherwise the default
+// "<dbDir>/GeoLite2-*.mmdb" location.
+func (i *IPInfo) cityDBLocation() (path, base string) {
+	if i.cityDBPath != "" {
+		return i.cityDBPath, filepath.Base(i.cityDBPath)
+	}
+	return filepath.Join(i.dbDir, cityDBName), cityDBName
+}
+
+func (i *IPInfo) asnDBLocation() (path, base string) {
+	if i.asnDBPath != "" {
+		return i.asnDBPath, filepath.Base(i.asnDBPath)
+	}
+	return filepath.Join(i.dbDir, asnDBName), asnDBName
+}
+
+// Watch starts an fsnotify watcher on dbDir (plus the directories of any
+// cityDBPath/asnDBPath overrides) and hot-swaps the city/ASN readers
+// whenever the underlying mmdb files are written or renamed, so a
+// long-running scanner picks up refreshed GeoIP data without a restart.
+// It returns once the watcher is installed; reloads happen in a background
+// goroutine until ctx is canceled or Close is called.
+func (i *IPInfo) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %v", err)
+	}
+
+	watched := map[string]bool{}
+	dirs := []string{i.dbDir}
+	if i.cityDBPath != "" {
+		dirs = append(dirs, filepath.Dir(i.cityDBPath))
+	}
+	if i.asnDBPath != "" {
+		dirs = append(dirs, filepath.Dir(i.asnDBPath))
+	}
+
+	for _, dir := range dirs {
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %v", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	i.watcher = watcher
+	i.done = make(chan struct{})
+
+	go i.watchLoop(ctx, watcher)
+
+	return nil
+}
+
+func (i *IPInfo) watchLoop(ctx context.Context, watcher *fsnotify.Watcher) {
+	_, cityBase := i.cityDBLocation()
+	_, asnBase := i.asnDBLocation()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-i.done:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			switch filepath.Base(event.Name) {
+			case cityBase:
+				if err := i.reloadCityDB(); err != nil {
+					log.Printf("ipinfo: failed to reload %s: %v", cityBase, err)
+				}
+			case asnBase:
+				if err := i.reloadASNDB(); err != nil {
+					log.Printf("ipinfo: failed to reload %s: %v", asnBase, err)
+				}
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("ipinfo: watcher error: %v", err)
+		}
+	}
+}
+
+func (i *IPInfo) reloadCityDB() error {
+	cityDB, _ := i.cityDBLocation()
+	reader, err := geoip2.Open(cityDB)
+	if err != nil {
+		return fmt.Errorf("failed to open reloaded city database: %v", err)
+	}
+
+	i.mu.Lock()
+	old := i.cityReader
+	i.cityReader = newMMDBReader(reader, dbKindCity)
+	i.mu.Unlock()
+
+	closeGeoReader(old)
+	if i.cache != nil {
+		i.cache.clear()
+	}
+	return nil
+}
+
+func (i *IPInfo) reloadASNDB() error {
+	asnDB, _ := i.asnDBLocation()
+	reader, err := geoip2.Open(asnDB)
+	if err != nil {
+		return fmt.Errorf("failed to open reloaded ASN database: %v", err)
+	}
+
+	i.mu.Lock()
+	old := i.asnReader
+	i.asnReader = newMMDBReader(reader, dbKindASN)
+	i.mu.Unlock()
+
+	closeGeoReader(old)
+	if i.cache != nil {
+		i.cache.clear()
+	}
+	return nil
+}
+
+// closeGeoReader closes reader if it wraps an open *geoip2.Reader.
+func closeGeoReader(reader GeoReader) {
+	if mr, ok := reader.(*mmdbReader); ok {
+		mr.Close()
+	}
+}
+
+// StartAutoUpdate runs a ticker at UpdateInterval that re-downloads any
+// database whose remote Last-Modified/ETag no longer matches the cached
+// value for that file, writing atomically so Watch can pick up the change.
+// Databases opened from a cityDBPath/asnDBPath override are skipped - per
+// Options.CityDBPath/ASNDBPath, those are never downloaded into. It blocks
+// until ctx is canceled, so callers should run it in a goroutine.
+func (i *IPInfo) StartAutoUpdate(ctx context.Context) {
+	if i.UpdateInterval <= 0 || i.offline {
+		return
+	}
+
+	ticker := time.NewTicker(i.UpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if i.cityDBPath == "" {
+				if err := i.checkForUpdate(cityDBName, i.cityDBURL); err != nil {
+					log.Printf("ipinfo: city database update check failed: %v", err)
+				}
+			}
+			if i.asnDBPath == "" {
+				if err := i.checkForUpdate(asnDBName, i.asnDBURL); err != nil {
+					log.Printf("ipinfo: ASN database update check failed: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// checkForUpdate compares the remote Last-Modified/ETag for name against the
+// value cached in "<name>.meta" and re-downloads the database if they differ.
+func (i *IPInfo) checkForUpdate(name, url string) error {
+	if i.MaxMindAccountID != "" && i.MaxMindLicenseKey != "" {
+		// The permalink endpoint doesn't expose a cheap way to check
+		// for freshness without downloading, so just re-fetch.
+		return i.downloadVerified(editionForName(name), url, filepath.Join(i.dbDir, name))
+	}
+
+	resp, err := http.Head(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	current := resp.Header.Get("Last-Modified")
+	if current == "" {
+		current = resp.Header.Get("ETag")
+	}
+	if current == "" {
+		return nil
+	}
+
+	metaPath := filepath.Join(i.dbDir, name+".meta")
+	cached, _ := os.ReadFile(metaPath)
+	if string(cached) == current {
+		return nil
+	}
+
+	dest := filepath.Join(i.dbDir, name)
+	if err := i.downloadVerified(editionForName(name), url, dest); err != nil {
+		return err
+	}
+
+	return os.WriteFile(metaPath, []byte(current), 0644)
+}
+
+func editionForName(name string) string {
+	switch name {
+	case cityDBName:
+		return "GeoLite2-City"
+	case asnDBName:
+		return "GeoLite2-ASN"
+	default:
+		return ""
+	}
+}
+
+// GetIPInfo resolves ip using i.DefaultLanguage (or "en" if unset) for
+// localized names.
 func (i *IPInfo) GetIPInfo(ip string) (*IPDetails, error) {
+	lang := i.DefaultLanguage
+	if lang == "" {
+		lang = "en"
+	}
+	return i.GetIPInfoLang(ip, lang)
+}
+
+// GetIPInfoLang resolves ip the same way GetIPInfo does, but renders
+// localized names (Country, City, Region, Continent) in lang, falling back
+// to English for any name MaxMind didn't ship a translation for.
+func (i *IPInfo) GetIPInfoLang(ip, lang string) (*IPDetails, error) {
+	if i.cache != nil {
+		if details, ok := i.cache.get(cacheKey{ip: ip, lang: lang}); ok {
+			return details, nil
+		}
+	}
+
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
@@ -97,11 +532,11 @@ func (i *IPInfo) GetIPInfo(ip string) (*IPDetails, error) {
 	}
 
 	details := &IPDetails{
-		Continent:     getLocalizedName(city.Continent.Names, "en"),
+		Continent:     getLocalizedName(city.Continent.Names, lang),
 		ContinentCode: city.Continent.Code,
-		Country:       getLocalizedName(city.Country.Names, "en"),
+		Country:       getLocalizedName(city.Country.Names, lang),
 		CountryCode:   city.Country.IsoCode,
-		City:          getLocalizedName(city.City.Names, "en"),
+		City:          getLocalizedName(city.City.Names, lang),
 		TimeZone:      city.Location.TimeZone,
 
 		PostalCode: city.Postal.Code,
@@ -113,28 +548,79 @@ func (i *IPInfo) GetIPInfo(ip string) (*IPDetails, error) {
 		ASNOrg: asn.AutonomousSystemOrganization,
 
 		AccuracyRadius: uint16(city.Location.AccuracyRadius),
+
+		CountryNames: city.Country.Names,
+		CityNames:    city.City.Names,
 	}
 
 	if len(city.Subdivisions) > 0 {
-		details.Region = getLocalizedName(city.Subdivisions[0].Names, "en")
+		details.Region = getLocalizedName(city.Subdivisions[0].Names, lang)
 		details.RegionCode = city.Subdivisions[0].IsoCode
 	}
 
+	i.enrichFromOptionalDatabases(parsedIP, lang, details)
+
+	if i.cache != nil {
+		i.cache.put(cacheKey{ip: ip, lang: lang}, details)
+	}
+
 	return details, nil
 }
 
-func (i *IPInfo) Close() {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+// enrichFromOptionalDatabases fills in the IPDetails fields backed by the
+// Country/Enterprise/Anonymous IP databases, if they were configured. It's
+// a no-op for any reader that's empty.
+func (i *IPInfo) enrichFromOptionalDatabases(parsedIP net.IP, lang string, details *IPDetails) {
+	if !i.countryReader.IsEmpty() {
+		if country, err := i.countryReader.Country(parsedIP); err == nil {
+			details.Country = getLocalizedName(country.Country.Names, lang)
+			details.CountryCode = country.Country.IsoCode
+			details.CountryNames = country.Country.Names
+		}
+	}
 
-	if i.cityReader != nil {
-		i.cityReader.Close()
+	if !i.anonymousReader.IsEmpty() {
+		if anon, err := i.anonymousReader.AnonymousIP(parsedIP); err == nil {
+			details.IsAnonymous = anon.IsAnonymous
+			details.IsAnonymousVPN = anon.IsAnonymousVPN
+			details.IsHosting = anon.IsHostingProvider
+			details.IsTorExitNode = anon.IsTorExitNode
+		}
 	}
-	if i.asnReader != nil {
-		i.asnReader.Close()
+
+	if !i.enterpriseReader.IsEmpty() {
+		if ent, err := i.enterpriseReader.Enterprise(parsedIP); err == nil {
+			details.ISP = ent.Traits.ISP
+			details.Domain = ent.Traits.Domain
+			details.NetworkType = ent.Traits.ConnectionType
+			details.IsProxy = ent.Traits.IsAnonymousProxy
+			details.AccuracyRadius = uint16(ent.Location.AccuracyRadius)
+		}
 	}
 }
 
+// Close releases the watcher and mmdb readers. It is safe to call more than
+// once - only the first call has any effect.
+func (i *IPInfo) Close() {
+	i.closeOnce.Do(func() {
+		if i.done != nil {
+			close(i.done)
+		}
+		if i.watcher != nil {
+			i.watcher.Close()
+		}
+
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		closeGeoReader(i.cityReader)
+		closeGeoReader(i.asnReader)
+		closeGeoReader(i.enterpriseReader)
+		closeGeoReader(i.anonymousReader)
+		closeGeoReader(i.countryReader)
+	})
+}
+
 func getLocalizedName(names map[string]string, lang string) string {
 	if names == nil {
 		return ""
@@ -149,41 +635,57 @@ func getLocalizedName(names map[string]string, lang string) string {
 	return ""
 }
 
-// ensureDatabases checks if required databases exist and downloads them if necessary
+// ensureDatabases checks if required databases exist and downloads them if
+// necessary. If i.cityDBPath/asnDBPath are set, those paths are opened
+// directly and never downloaded into. If i.offline is set, a missing
+// database is a hard error rather than a network fetch.
 func (i *IPInfo) ensureDatabases() error {
-	cityDB := filepath.Join(i.dbDir, "GeoLite2-City.mmdb")
-	asnDB := filepath.Join(i.dbDir, "GeoLite2-ASN.mmdb")
+	cityDB := i.cityDBPath
+	if cityDB == "" {
+		cityDB = filepath.Join(i.dbDir, cityDBName)
+	}
+	asnDB := i.asnDBPath
+	if asnDB == "" {
+		asnDB = filepath.Join(i.dbDir, asnDBName)
+	}
 
 	// Download databases if they don't exist
 	if _, err := os.Stat(cityDB); os.IsNotExist(err) {
-		if err := downloadFile(cityDBURL, cityDB); err != nil {
+		if i.offline || i.cityDBPath != "" {
+			return fmt.Errorf("city database not found at %s and offline mode/explicit path disables downloading it", cityDB)
+		}
+		if err := i.downloadVerified("GeoLite2-City", i.cityDBURL, cityDB); err != nil {
 			return fmt.Errorf("failed to download city database: %v", err)
 		}
 	}
 
 	if _, err := os.Stat(asnDB); os.IsNotExist(err) {
-		if err := downloadFile(asnDBURL, asnDB); err != nil {
+		if i.offline || i.asnDBPath != "" {
+			return fmt.Errorf("ASN database not found at %s and offline mode/explicit path disables downloading it", asnDB)
+		}
+		if err := i.downloadVerified("GeoLite2-ASN", i.asnDBURL, asnDB); err != nil {
 			return fmt.Errorf("failed to download ASN database: %v", err)
 		}
 	}
 
 	// Open databases
-	var err error
-	i.cityReader, err = geoip2.Open(cityDB)
+	cityHandle, err := geoip2.Open(cityDB)
 	if err != nil {
 		return fmt.Errorf("failed to open city database: %v", err)
 	}
+	i.cityReader = newMMDBReader(cityHandle, dbKindCity)
 
-	i.asnReader, err = geoip2.Open(asnDB)
+	asnHandle, err := geoip2.Open(asnDB)
 	if err != nil {
-		i.cityReader.Close()
+		cityHandle.Close()
 		return fmt.Errorf("failed to open ASN database: %v", err)
 	}
+	i.asnReader = newMMDBReader(asnHandle, dbKindASN)
 
 	return nil
 }
 
-func downloadFile(url, filepath string) error {
+func downloadFile(url, path string) error {
 	resp, err := http.Get(url)
 	if err != nil {
 		return err
@@ -194,12 +696,21 @@ func downloadFile(url, filepath string) error {
 		return fmt.Errorf("bad status: %s", resp.Status)
 	}
 
-	out, err := os.Create(filepath)
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
-	return err
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
 }
@@ -0,0 +1,71 @@
+package iprule
+
+import (
+	"net"
+
+	"github.com/zcyberseclab/zscan/pkg/stage"
+)
+
+// FilterTargets is the integration point between the rule DSL and the scan
+// pipeline: it resolves each target through info and applies skip/only rule
+// sets the way --skip-rule/--only-rule are expected to behave. A target is
+// kept if it matches no skip rule and, when onlyRules is non-empty, matches
+// at least one only rule. See TagTargets to report which rule matched
+// instead of filtering.
+func FilterTargets(targets []string, info *stage.IPInfo, skipRules, onlyRules *RuleSet) []string {
+	kept := make([]string, 0, len(targets))
+
+	for _, target := range targets {
+		ip := net.ParseIP(target)
+
+		var details *stage.IPDetails
+		if ip != nil && !info.IsEmpty() {
+			details, _ = info.GetIPInfo(target)
+		}
+
+		if skipRules.Any(ip, details) {
+			continue
+		}
+		if !onlyRules.Empty() && !onlyRules.Any(ip, details) {
+			continue
+		}
+
+		kept = append(kept, target)
+	}
+
+	return kept
+}
+
+// TaggedTarget pairs a target with the skip/only rules (rendered in the
+// "TYPE,VALUE" DSL, e.g. "IP-ASN,15169") that matched its resolved GeoIP
+// details.
+type TaggedTarget struct {
+	Target      string
+	MatchedSkip []string
+	MatchedOnly []string
+}
+
+// TagTargets resolves each target the same way FilterTargets does, but
+// returns every target tagged with which skipRules/onlyRules matched
+// instead of filtering any out - for callers that want to report which
+// rule and value decided a target's fate rather than just apply it.
+func TagTargets(targets []string, info *stage.IPInfo, skipRules, onlyRules *RuleSet) []TaggedTarget {
+	tagged := make([]TaggedTarget, 0, len(targets))
+
+	for _, target := range targets {
+		ip := net.ParseIP(target)
+
+		var details *stage.IPDetails
+		if ip != nil && !info.IsEmpty() {
+			details, _ = info.GetIPInfo(target)
+		}
+
+		tagged = append(tagged, TaggedTarget{
+			Target:      target,
+			MatchedSkip: skipRules.MatchingRules(ip, details),
+			MatchedOnly: onlyRules.MatchingRules(ip, details),
+		})
+	}
+
+	return tagged
+}
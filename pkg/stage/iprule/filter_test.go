@@ -0,0 +1,35 @@
+package iprule
+
+import "testing"
+
+func TestFilterTargets_NilInfoStillAppliesIPRules(t *testing.T) {
+	skip, err := NewRuleSetFromSpecs([]string{"IP-BOGON"})
+	if err != nil {
+		t.Fatalf("NewRuleSetFromSpecs: %v", err)
+	}
+
+	kept := FilterTargets([]string{"8.8.8.8", "192.168.1.1"}, nil, skip, nil)
+	want := []string{"8.8.8.8"}
+	if len(kept) != len(want) || kept[0] != want[0] {
+		t.Errorf("FilterTargets(nil info) = %v, want %v", kept, want)
+	}
+}
+
+func TestTagTargets(t *testing.T) {
+	skip, err := NewRuleSetFromSpecs([]string{"IP-BOGON"})
+	if err != nil {
+		t.Fatalf("NewRuleSetFromSpecs: %v", err)
+	}
+
+	tagged := TagTargets([]string{"192.168.1.1", "8.8.8.8"}, nil, skip, nil)
+	if len(tagged) != 2 {
+		t.Fatalf("TagTargets: got %d results, want 2", len(tagged))
+	}
+
+	if tagged[0].Target != "192.168.1.1" || len(tagged[0].MatchedSkip) != 1 || tagged[0].MatchedSkip[0] != "IP-BOGON" {
+		t.Errorf("TagTargets[0] = %+v, want MatchedSkip=[IP-BOGON]", tagged[0])
+	}
+	if tagged[1].Target != "8.8.8.8" || len(tagged[1].MatchedSkip) != 0 {
+		t.Errorf("TagTargets[1] = %+v, want no matched skip rules", tagged[1])
+	}
+}
@@ -0,0 +1,72 @@
+package iprule
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseRule parses a single rule in the "TYPE,VALUE" DSL, e.g.:
+//
+//	IP-ASN,15169
+//	IP-COUNTRY,US
+//	IP-CIDR,10.0.0.0/8
+//	IP-BOGON
+func ParseRule(spec string) (Rule, error) {
+	spec = strings.TrimSpace(spec)
+	parts := strings.SplitN(spec, ",", 2)
+	kind := strings.ToUpper(strings.TrimSpace(parts[0]))
+
+	var value string
+	if len(parts) == 2 {
+		value = strings.TrimSpace(parts[1])
+	}
+
+	switch kind {
+	case "IP-ASN":
+		if value == "" {
+			return nil, fmt.Errorf("iprule: %s requires an ASN value", kind)
+		}
+		asn, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("iprule: invalid ASN %q: %v", value, err)
+		}
+		return asnRule{asn: uint(asn)}, nil
+
+	case "IP-COUNTRY":
+		if value == "" {
+			return nil, fmt.Errorf("iprule: %s requires a country code", kind)
+		}
+		return countryRule{code: strings.ToUpper(value)}, nil
+
+	case "IP-CIDR":
+		if value == "" {
+			return nil, fmt.Errorf("iprule: %s requires a CIDR value", kind)
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("iprule: invalid CIDR %q: %v", value, err)
+		}
+		return cidrRule{network: network}, nil
+
+	case "IP-BOGON":
+		return newBogonRule(), nil
+
+	default:
+		return nil, fmt.Errorf("iprule: unknown rule type %q", kind)
+	}
+}
+
+// ParseRules parses each spec with ParseRule, stopping at the first error.
+func ParseRules(specs []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(specs))
+	for _, spec := range specs {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
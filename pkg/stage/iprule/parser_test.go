@@ -0,0 +1,87 @@
+package iprule
+
+import (
+	"net"
+	"testing"
+
+	"github.com/zcyberseclab/zscan/pkg/stage"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+		match   net.IP
+		details *stage.IPDetails
+		want    bool
+	}{
+		{spec: "IP-ASN,15169", match: net.ParseIP("8.8.8.8"), details: &stage.IPDetails{ASN: 15169}, want: true},
+		{spec: "IP-ASN,15169", match: net.ParseIP("8.8.8.8"), details: &stage.IPDetails{ASN: 13335}, want: false},
+		{spec: "IP-ASN,", wantErr: true},
+		{spec: "IP-ASN,not-a-number", wantErr: true},
+		{spec: "ip-country,us", match: net.ParseIP("1.1.1.1"), details: &stage.IPDetails{CountryCode: "US"}, want: true},
+		{spec: "IP-COUNTRY,", wantErr: true},
+		{spec: "IP-CIDR,10.0.0.0/8", match: net.ParseIP("10.1.2.3"), want: true},
+		{spec: "IP-CIDR,10.0.0.0/8", match: net.ParseIP("11.1.2.3"), want: false},
+		{spec: "IP-CIDR,not-a-cidr", wantErr: true},
+		{spec: "IP-CIDR,", wantErr: true},
+		{spec: "IP-BOGON", match: net.ParseIP("192.168.1.1"), want: true},
+		{spec: "IP-BOGON", match: net.ParseIP("8.8.8.8"), want: false},
+		{spec: "IP-NONSENSE", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRule(%q): expected error, got nil", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseRule(%q): unexpected error: %v", tt.spec, err)
+		}
+		if got := rule.Match(tt.match, tt.details); got != tt.want {
+			t.Errorf("ParseRule(%q).Match(%v, %v) = %v, want %v", tt.spec, tt.match, tt.details, got, tt.want)
+		}
+	}
+}
+
+func TestRule_String(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "IP-ASN,15169", want: "IP-ASN,15169"},
+		{spec: "IP-COUNTRY,us", want: "IP-COUNTRY,US"},
+		{spec: "IP-CIDR,10.0.0.0/8", want: "IP-CIDR,10.0.0.0/8"},
+		{spec: "IP-BOGON", want: "IP-BOGON"},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseRule(%q): unexpected error: %v", tt.spec, err)
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRule(%q).String() = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseRules_StopsAtFirstError(t *testing.T) {
+	_, err := ParseRules([]string{"IP-BOGON", "IP-ASN,bad", "IP-CIDR,10.0.0.0/8"})
+	if err == nil {
+		t.Fatal("ParseRules: expected error for invalid ASN spec")
+	}
+}
+
+func TestParseRules_AllValid(t *testing.T) {
+	rules, err := ParseRules([]string{"IP-BOGON", "IP-ASN,15169", "IP-CIDR,10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParseRules: unexpected error: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("ParseRules: got %d rules, want 3", len(rules))
+	}
+}
@@ -0,0 +1,117 @@
+// Package iprule implements an IP-ASN/IP-CIDR/IP-COUNTRY/IP-BOGON rule DSL
+// for targeting decisions, similar in spirit to the rule-set matchers found
+// in proxy/routing tools. Rules are evaluated against the GeoIP enrichment
+// zscan already computes via stage.IPInfo, so geo/ASN data collected for
+// reporting can also drive which targets a scan includes or skips.
+package iprule
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/zcyberseclab/zscan/pkg/stage"
+)
+
+// Rule matches a single IP against one condition, such as "belongs to ASN
+// 15169" or "is a known bogon address". details may be nil if no GeoIP
+// enrichment was available for ip; rules that need it should treat a nil
+// details as a non-match rather than panicking.
+//
+// String renders the rule back in the "TYPE,VALUE" DSL it was parsed from
+// (e.g. "IP-ASN,15169"), so a match can be used to tag a result with which
+// rule and value fired rather than just its bare type.
+type Rule interface {
+	Match(ip net.IP, details *stage.IPDetails) bool
+	String() string
+}
+
+type asnRule struct {
+	asn uint
+}
+
+func (r asnRule) Match(ip net.IP, details *stage.IPDetails) bool {
+	return details != nil && details.ASN == r.asn
+}
+
+func (r asnRule) String() string {
+	return fmt.Sprintf("IP-ASN,%d", r.asn)
+}
+
+type countryRule struct {
+	code string
+}
+
+func (r countryRule) Match(ip net.IP, details *stage.IPDetails) bool {
+	return details != nil && details.CountryCode == r.code
+}
+
+func (r countryRule) String() string {
+	return "IP-COUNTRY," + r.code
+}
+
+type cidrRule struct {
+	network *net.IPNet
+}
+
+func (r cidrRule) Match(ip net.IP, details *stage.IPDetails) bool {
+	return ip != nil && r.network.Contains(ip)
+}
+
+func (r cidrRule) String() string {
+	return "IP-CIDR," + r.network.String()
+}
+
+// bogonRanges lists the well-known private/reserved/special-use ranges that
+// never appear as legitimate public scan targets.
+var bogonRanges = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+type bogonRule struct {
+	networks []*net.IPNet
+}
+
+func newBogonRule() bogonRule {
+	r := bogonRule{networks: make([]*net.IPNet, 0, len(bogonRanges))}
+	for _, cidr := range bogonRanges {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			// bogonRanges is a fixed, known-good list, so this can't happen.
+			panic("iprule: invalid bogon range " + cidr)
+		}
+		r.networks = append(r.networks, network)
+	}
+	return r
+}
+
+func (r bogonRule) Match(ip net.IP, details *stage.IPDetails) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range r.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r bogonRule) String() string {
+	return "IP-BOGON"
+}
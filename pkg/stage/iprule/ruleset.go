@@ -0,0 +1,79 @@
+package iprule
+
+import (
+	"net"
+
+	"github.com/zcyberseclab/zscan/pkg/stage"
+)
+
+// RuleSet composes multiple Rules for the two predicates scan targeting
+// needs: "matches any of these" (e.g. --only-rule) and "matches all of
+// these".
+type RuleSet struct {
+	rules []Rule
+}
+
+// NewRuleSet builds a RuleSet from already-parsed rules.
+func NewRuleSet(rules ...Rule) *RuleSet {
+	return &RuleSet{rules: rules}
+}
+
+// NewRuleSetFromSpecs parses each spec in the "TYPE,VALUE" DSL and builds a
+// RuleSet from the result.
+func NewRuleSetFromSpecs(specs []string) (*RuleSet, error) {
+	rules, err := ParseRules(specs)
+	if err != nil {
+		return nil, err
+	}
+	return NewRuleSet(rules...), nil
+}
+
+// Any reports whether ip/details match at least one rule in the set. A nil
+// or empty RuleSet matches nothing.
+func (rs *RuleSet) Any(ip net.IP, details *stage.IPDetails) bool {
+	if rs == nil {
+		return false
+	}
+	for _, rule := range rs.rules {
+		if rule.Match(ip, details) {
+			return true
+		}
+	}
+	return false
+}
+
+// All reports whether ip/details match every rule in the set. A nil or
+// empty RuleSet matches everything.
+func (rs *RuleSet) All(ip net.IP, details *stage.IPDetails) bool {
+	if rs == nil {
+		return true
+	}
+	for _, rule := range rs.rules {
+		if !rule.Match(ip, details) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the RuleSet has no rules.
+func (rs *RuleSet) Empty() bool {
+	return rs == nil || len(rs.rules) == 0
+}
+
+// MatchingRules returns the String() (e.g. "IP-ASN,15169") of every rule in
+// the set that matches ip/details, in rule order, for tagging a result with
+// which rules and values fired. A nil or empty RuleSet always returns nil.
+func (rs *RuleSet) MatchingRules(ip net.IP, details *stage.IPDetails) []string {
+	if rs.Empty() {
+		return nil
+	}
+
+	var matched []string
+	for _, rule := range rs.rules {
+		if rule.Match(ip, details) {
+			matched = append(matched, rule.String())
+		}
+	}
+	return matched
+}
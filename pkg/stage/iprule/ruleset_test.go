@@ -0,0 +1,81 @@
+package iprule
+
+import (
+	"net"
+	"testing"
+
+	"github.com/zcyberseclab/zscan/pkg/stage"
+)
+
+func TestRuleSet_NilIsNoOp(t *testing.T) {
+	var rs *RuleSet
+
+	if !rs.Empty() {
+		t.Error("nil RuleSet: Empty() = false, want true")
+	}
+	if rs.Any(net.ParseIP("8.8.8.8"), nil) {
+		t.Error("nil RuleSet: Any() = true, want false")
+	}
+	if !rs.All(net.ParseIP("8.8.8.8"), nil) {
+		t.Error("nil RuleSet: All() = false, want true")
+	}
+}
+
+func TestRuleSet_Any(t *testing.T) {
+	rs, err := NewRuleSetFromSpecs([]string{"IP-ASN,15169", "IP-COUNTRY,US"})
+	if err != nil {
+		t.Fatalf("NewRuleSetFromSpecs: %v", err)
+	}
+	if rs.Empty() {
+		t.Fatal("RuleSet with two specs reported Empty()")
+	}
+
+	ip := net.ParseIP("8.8.8.8")
+	if !rs.Any(ip, &stage.IPDetails{ASN: 15169, CountryCode: "DE"}) {
+		t.Error("Any(): expected match on ASN")
+	}
+	if !rs.Any(ip, &stage.IPDetails{ASN: 1, CountryCode: "US"}) {
+		t.Error("Any(): expected match on country")
+	}
+	if rs.Any(ip, &stage.IPDetails{ASN: 1, CountryCode: "DE"}) {
+		t.Error("Any(): expected no match")
+	}
+}
+
+func TestRuleSet_All(t *testing.T) {
+	rs, err := NewRuleSetFromSpecs([]string{"IP-ASN,15169", "IP-COUNTRY,US"})
+	if err != nil {
+		t.Fatalf("NewRuleSetFromSpecs: %v", err)
+	}
+
+	ip := net.ParseIP("8.8.8.8")
+	if !rs.All(ip, &stage.IPDetails{ASN: 15169, CountryCode: "US"}) {
+		t.Error("All(): expected match on both rules")
+	}
+	if rs.All(ip, &stage.IPDetails{ASN: 15169, CountryCode: "DE"}) {
+		t.Error("All(): expected no match when only one rule matches")
+	}
+}
+
+func TestRuleSet_MatchingRules(t *testing.T) {
+	var nilRS *RuleSet
+	if got := nilRS.MatchingRules(net.ParseIP("8.8.8.8"), nil); got != nil {
+		t.Errorf("nil RuleSet: MatchingRules() = %v, want nil", got)
+	}
+
+	rs, err := NewRuleSetFromSpecs([]string{"IP-ASN,15169", "IP-COUNTRY,US", "IP-CIDR,10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("NewRuleSetFromSpecs: %v", err)
+	}
+
+	got := rs.MatchingRules(net.ParseIP("8.8.8.8"), &stage.IPDetails{ASN: 15169, CountryCode: "US"})
+	want := []string{"IP-ASN,15169", "IP-COUNTRY,US"}
+	if len(got) != len(want) {
+		t.Fatalf("MatchingRules() = %v, want %v", got, want)
+	}
+	for idx, w := range want {
+		if got[idx] != w {
+			t.Errorf("MatchingRules()[%d] = %q, want %q", idx, got[idx], w)
+		}
+	}
+}
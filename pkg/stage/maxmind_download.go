@@ -0,0 +1,78 @@
+package stage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// downloadMaxMindEdition fetches edition (e.g. "GeoLite2-City") from the
+// official MaxMind permalink endpoint using i.MaxMindAccountID/LicenseKey,
+// extracts the .mmdb file from the returned tar.gz, and atomically installs
+// it at dest.
+func (i *IPInfo) downloadMaxMindEdition(edition, dest string) error {
+	if edition == "" {
+		return fmt.Errorf("unknown database edition for %s", dest)
+	}
+
+	url := fmt.Sprintf(maxMindPermalinkURL, edition)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(i.MaxMindAccountID, i.MaxMindLicenseKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("maxmind download failed: %s", resp.Status)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decompress maxmind archive: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("maxmind archive for %s did not contain an mmdb file", edition)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read maxmind archive: %v", err)
+		}
+
+		if !strings.HasSuffix(header.Name, ".mmdb") {
+			continue
+		}
+
+		tmpPath := dest + ".tmp"
+		out, err := os.Create(tmpPath)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+		if err := out.Close(); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+
+		return os.Rename(tmpPath, dest)
+	}
+}
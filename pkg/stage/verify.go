@@ -0,0 +1,133 @@
+package stage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// downloadRetries is how many download attempts downloadVerified makes in
+// total before giving up on a failed or corrupt download, backing off
+// exponentially between attempts starting at one second.
+const downloadRetries = 3
+
+// downloadVerified downloads edition (via i's MaxMind account if configured,
+// otherwise from url) to dest, verifying the result with Verify and, when
+// available, a sibling SHA256 checksum. A failed or corrupt download is
+// deleted and retried with exponential backoff rather than left on disk to
+// poison later opens.
+func (i *IPInfo) downloadVerified(edition, url, dest string) error {
+	useMaxMind := i.MaxMindAccountID != "" && i.MaxMindLicenseKey != ""
+
+	var lastErr error
+	backoff := time.Second
+
+	for attempt := 0; attempt < downloadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		if useMaxMind {
+			lastErr = i.downloadMaxMindEdition(edition, dest)
+		} else {
+			lastErr = downloadFile(url, dest)
+		}
+		if lastErr != nil {
+			continue
+		}
+
+		if lastErr = Verify(dest); lastErr != nil {
+			os.Remove(dest)
+			continue
+		}
+
+		if !useMaxMind {
+			if lastErr = verifyChecksum(dest, url); lastErr != nil {
+				os.Remove(dest)
+				continue
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %v", downloadRetries, lastErr)
+}
+
+// expectedDBTypes maps an mmdb file's on-disk name to the DatabaseType
+// MaxMind stamps in its metadata, so a truncated or mismatched download is
+// rejected before geoip2 ever opens it.
+var expectedDBTypes = map[string]string{
+	cityDBName: "GeoLite2-City",
+	asnDBName:  "GeoLite2-ASN",
+}
+
+// Verify opens path as an mmdb file, reads its metadata, and rejects it if
+// the DatabaseType doesn't match what's expected for that file name. It
+// closes the file before returning either way.
+func Verify(path string) error {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for verification: %v", path, err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata
+
+	want, ok := expectedDBTypes[filepath.Base(path)]
+	if ok && meta.DatabaseType != want {
+		return fmt.Errorf("%s: expected database type %q, got %q (build epoch %d, %d nodes)",
+			path, want, meta.DatabaseType, meta.BuildEpoch, meta.NodeCount)
+	}
+
+	return nil
+}
+
+// verifyChecksum fetches sourceURL+".sha256" and compares it against the
+// SHA256 of the downloaded file at path. A missing checksum file (404) is
+// treated as "not offered" rather than a failure, since most mirrors don't
+// publish one.
+func verifyChecksum(path, sourceURL string) error {
+	resp, err := http.Get(sourceURL + ".sha256")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	want, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum for %s: %v", sourceURL, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+
+	got := hex.EncodeToString(hasher.Sum(nil))
+	wantHash := strings.Fields(string(want))[0]
+	if !strings.EqualFold(got, wantHash) {
+		return fmt.Errorf("%s: checksum mismatch: got %s, want %s", path, got, wantHash)
+	}
+
+	return nil
+}